@@ -0,0 +1,243 @@
+// Package policy evaluates an admission-style policy against the
+// attestations collected for this binary's own image before the HTTP
+// server starts serving. Policies are either Rego (evaluated with OPA) or
+// a Cosign policy-controller-style YAML document; which one is used is
+// decided by the policy file's extension.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/rego"
+	"gopkg.in/yaml.v3"
+)
+
+// Mode controls what happens when a policy fails to pass.
+type Mode string
+
+const (
+	// ModeEnforce causes main to exit non-zero when the policy fails.
+	ModeEnforce Mode = "enforce"
+	// ModeWarn causes the server to start anyway, reporting "degraded" on
+	// /health.
+	ModeWarn Mode = "warn"
+)
+
+// Input is the evaluated document: everything the policy needs to know
+// about the attestations collected for this image.
+type Input struct {
+	ImageDigest               string         `json:"image_digest"`
+	SignatureVerified         bool           `json:"signature_verified"`
+	SignerIssuer              string         `json:"signer_issuer"`
+	SignerIdentity            string         `json:"signer_identity"`
+	AttestationPredicateTypes []string       `json:"attestation_predicate_types"`
+	SLSABuildLevel            int            `json:"slsa_build_level"`
+	Vulnerabilities           map[string]int `json:"vulnerabilities"`
+	// VulnerabilitiesUnavailable is set when the vulnerability summary
+	// could not be loaded. maxVulnerabilities rules fail closed in this
+	// case rather than passing against a zero-value summary.
+	VulnerabilitiesUnavailable bool `json:"vulnerabilities_unavailable,omitempty"`
+}
+
+// RuleResult is the pass/fail outcome of a single policy rule.
+type RuleResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// Result is the outcome of evaluating a policy once.
+type Result struct {
+	Passed      bool       `json:"passed"`
+	Mode        Mode       `json:"mode"`
+	Rules       []RuleResult `json:"rules"`
+	Input       Input      `json:"input"`
+	EvaluatedAt time.Time  `json:"evaluated_at"`
+}
+
+// yamlSpec is the Cosign policy-controller-style policy shape.
+type yamlSpec struct {
+	RequiredPredicateTypes []string `yaml:"requiredPredicateTypes"`
+	RequiredIssuer         string   `yaml:"requiredIssuer"`
+	RequiredSubjectRegex   string   `yaml:"requiredSubjectRegex"`
+	MinSLSABuildLevel      int      `yaml:"minSlsaBuildLevel"`
+	MaxVulnerabilities     map[string]int `yaml:"maxVulnerabilities"`
+}
+
+// Engine evaluates a loaded policy against an Input.
+type Engine struct {
+	mode Mode
+
+	yaml *yamlSpec // set when the policy file is YAML
+	rego string    // set to the Rego source when the policy file is .rego
+}
+
+// Load reads the policy file at path and builds an Engine for it. The file
+// extension selects the engine: ".rego" is evaluated with OPA, anything
+// else is parsed as policy-controller-style YAML.
+func Load(path string, mode Mode) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file %q: %w", path, err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".rego") {
+		return &Engine{mode: mode, rego: string(data)}, nil
+	}
+
+	var spec yamlSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parsing policy YAML %q: %w", path, err)
+	}
+	return &Engine{mode: mode, yaml: &spec}, nil
+}
+
+// Evaluate runs the loaded policy against input and returns a Result with
+// per-rule pass/fail detail.
+func (e *Engine) Evaluate(ctx context.Context, input Input) (Result, error) {
+	result := Result{Mode: e.mode, Input: input, EvaluatedAt: time.Now().UTC()}
+
+	var rules []RuleResult
+	var err error
+	if e.rego != "" {
+		rules, err = e.evaluateRego(ctx, input)
+	} else {
+		rules = e.evaluateYAML(input)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result.Rules = rules
+	result.Passed = true
+	for _, rule := range rules {
+		if !rule.Passed {
+			result.Passed = false
+			break
+		}
+	}
+	return result, nil
+}
+
+func (e *Engine) evaluateYAML(input Input) []RuleResult {
+	spec := e.yaml
+	var rules []RuleResult
+
+	for _, required := range spec.RequiredPredicateTypes {
+		passed := containsString(input.AttestationPredicateTypes, required)
+		msg := ""
+		if !passed {
+			msg = fmt.Sprintf("missing required predicate type %q", required)
+		}
+		rules = append(rules, RuleResult{Name: "requiredPredicateType:" + required, Passed: passed, Message: msg})
+	}
+
+	if spec.RequiredIssuer != "" {
+		passed := input.SignerIssuer == spec.RequiredIssuer
+		msg := ""
+		if !passed {
+			msg = fmt.Sprintf("signer issuer %q does not match required issuer %q", input.SignerIssuer, spec.RequiredIssuer)
+		}
+		rules = append(rules, RuleResult{Name: "requiredIssuer", Passed: passed, Message: msg})
+	}
+
+	if spec.RequiredSubjectRegex != "" {
+		matched, err := regexp.MatchString(spec.RequiredSubjectRegex, input.SignerIdentity)
+		passed := err == nil && matched
+		msg := ""
+		if err != nil {
+			msg = fmt.Sprintf("invalid requiredSubjectRegex: %v", err)
+		} else if !passed {
+			msg = fmt.Sprintf("signer identity %q does not match %q", input.SignerIdentity, spec.RequiredSubjectRegex)
+		}
+		rules = append(rules, RuleResult{Name: "requiredSubjectRegex", Passed: passed, Message: msg})
+	}
+
+	if spec.MinSLSABuildLevel > 0 {
+		passed := input.SLSABuildLevel >= spec.MinSLSABuildLevel
+		msg := ""
+		if !passed {
+			msg = fmt.Sprintf("SLSA build level %d is below required minimum %d", input.SLSABuildLevel, spec.MinSLSABuildLevel)
+		}
+		rules = append(rules, RuleResult{Name: "minSlsaBuildLevel", Passed: passed, Message: msg})
+	}
+
+	for severity, max := range spec.MaxVulnerabilities {
+		if input.VulnerabilitiesUnavailable {
+			rules = append(rules, RuleResult{
+				Name:    "maxVulnerabilities:" + severity,
+				Passed:  false,
+				Message: "vulnerability summary unavailable, failing closed",
+			})
+			continue
+		}
+		count := input.Vulnerabilities[severity]
+		passed := count <= max
+		msg := ""
+		if !passed {
+			msg = fmt.Sprintf("%d %s vulnerabilities exceeds maximum of %d", count, severity, max)
+		}
+		rules = append(rules, RuleResult{Name: "maxVulnerabilities:" + severity, Passed: passed, Message: msg})
+	}
+
+	if !input.SignatureVerified {
+		rules = append(rules, RuleResult{Name: "signatureVerified", Passed: false, Message: "image signature did not verify"})
+	}
+
+	return rules
+}
+
+// evaluateRego evaluates the loaded Rego policy, expecting a
+// `data.policy.violations` rule producing a set/array of violation
+// message strings; an empty set passes.
+func (e *Engine) evaluateRego(ctx context.Context, input Input) ([]RuleResult, error) {
+	query, err := rego.New(
+		rego.Query("data.policy.violations"),
+		rego.Module("policy.rego", e.rego),
+		rego.Input(input),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("preparing rego policy: %w", err)
+	}
+
+	resultSet, err := query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, fmt.Errorf("evaluating rego policy: %w", err)
+	}
+
+	var violations []string
+	if len(resultSet) > 0 && len(resultSet[0].Expressions) > 0 {
+		if raw, ok := resultSet[0].Expressions[0].Value.([]interface{}); ok {
+			for _, v := range raw {
+				if s, ok := v.(string); ok {
+					violations = append(violations, s)
+				}
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return []RuleResult{{Name: "rego.violations", Passed: true}}, nil
+	}
+
+	rules := make([]RuleResult, 0, len(violations))
+	for i, v := range violations {
+		rules = append(rules, RuleResult{Name: fmt.Sprintf("rego.violations[%d]", i), Passed: false, Message: v})
+	}
+	return rules, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
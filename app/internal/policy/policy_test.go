@@ -0,0 +1,148 @@
+package policy
+
+import "testing"
+
+func ruleResult(rules []RuleResult, name string) (RuleResult, bool) {
+	for _, r := range rules {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return RuleResult{}, false
+}
+
+func TestEvaluateYAML(t *testing.T) {
+	engine := &Engine{
+		mode: ModeEnforce,
+		yaml: &yamlSpec{
+			RequiredPredicateTypes: []string{"https://slsa.dev/provenance/v1"},
+			RequiredIssuer:         "https://accounts.example.com",
+			RequiredSubjectRegex:   `^https://github\.com/acme/`,
+			MinSLSABuildLevel:      3,
+			MaxVulnerabilities:     map[string]int{"critical": 0, "high": 2},
+		},
+	}
+
+	t.Run("fully passing input", func(t *testing.T) {
+		input := Input{
+			SignatureVerified:         true,
+			SignerIssuer:              "https://accounts.example.com",
+			SignerIdentity:            "https://github.com/acme/repo/.github/workflows/build.yml@refs/heads/main",
+			AttestationPredicateTypes: []string{"https://slsa.dev/provenance/v1"},
+			SLSABuildLevel:            3,
+			Vulnerabilities:           map[string]int{"critical": 0, "high": 1},
+		}
+		rules := engine.evaluateYAML(input)
+		for _, r := range rules {
+			if !r.Passed {
+				t.Errorf("rule %q unexpectedly failed: %s", r.Name, r.Message)
+			}
+		}
+	})
+
+	t.Run("missing predicate type and signer issuer", func(t *testing.T) {
+		input := Input{
+			SignatureVerified: true,
+			SignerIssuer:      "https://evil.example.com",
+			SignerIdentity:    "https://github.com/acme/repo/.github/workflows/build.yml@refs/heads/main",
+			SLSABuildLevel:    3,
+			Vulnerabilities:   map[string]int{},
+		}
+		rules := engine.evaluateYAML(input)
+
+		if r, ok := ruleResult(rules, "requiredPredicateType:https://slsa.dev/provenance/v1"); !ok || r.Passed {
+			t.Error("expected missing predicate type rule to fail")
+		}
+		if r, ok := ruleResult(rules, "requiredIssuer"); !ok || r.Passed {
+			t.Error("expected issuer mismatch rule to fail")
+		}
+	})
+
+	t.Run("subject regex mismatch", func(t *testing.T) {
+		input := Input{
+			SignatureVerified: true,
+			SignerIdentity:    "https://github.com/someone-else/repo",
+			SLSABuildLevel:    3,
+			Vulnerabilities:   map[string]int{},
+		}
+		rules := engine.evaluateYAML(input)
+		if r, ok := ruleResult(rules, "requiredSubjectRegex"); !ok || r.Passed {
+			t.Error("expected subject regex rule to fail")
+		}
+	})
+
+	t.Run("build level and vulnerability budget exceeded", func(t *testing.T) {
+		input := Input{
+			SignatureVerified: true,
+			SLSABuildLevel:    1,
+			Vulnerabilities:   map[string]int{"critical": 1, "high": 5},
+		}
+		rules := engine.evaluateYAML(input)
+		if r, ok := ruleResult(rules, "minSlsaBuildLevel"); !ok || r.Passed {
+			t.Error("expected SLSA build level rule to fail")
+		}
+		if r, ok := ruleResult(rules, "maxVulnerabilities:critical"); !ok || r.Passed {
+			t.Error("expected critical vulnerability budget rule to fail")
+		}
+		if r, ok := ruleResult(rules, "maxVulnerabilities:high"); !ok || r.Passed {
+			t.Error("expected high vulnerability budget rule to fail")
+		}
+	})
+
+	t.Run("unavailable vulnerability summary fails closed", func(t *testing.T) {
+		input := Input{
+			SignatureVerified:          true,
+			SignerIssuer:               "https://accounts.example.com",
+			SignerIdentity:             "https://github.com/acme/repo/.github/workflows/build.yml@refs/heads/main",
+			AttestationPredicateTypes:  []string{"https://slsa.dev/provenance/v1"},
+			SLSABuildLevel:             3,
+			VulnerabilitiesUnavailable: true,
+		}
+		rules := engine.evaluateYAML(input)
+		if r, ok := ruleResult(rules, "maxVulnerabilities:critical"); !ok || r.Passed {
+			t.Error("expected maxVulnerabilities:critical to fail closed when the summary is unavailable")
+		}
+		if r, ok := ruleResult(rules, "maxVulnerabilities:high"); !ok || r.Passed {
+			t.Error("expected maxVulnerabilities:high to fail closed when the summary is unavailable")
+		}
+	})
+
+	t.Run("unsigned image fails regardless of other rules", func(t *testing.T) {
+		rules := (&Engine{yaml: &yamlSpec{}}).evaluateYAML(Input{SignatureVerified: false})
+		if r, ok := ruleResult(rules, "signatureVerified"); !ok || r.Passed {
+			t.Error("expected signatureVerified rule to fail when the signature did not verify")
+		}
+	})
+}
+
+func TestEvaluateRego(t *testing.T) {
+	engine := &Engine{
+		mode: ModeEnforce,
+		rego: `package policy
+
+violations[msg] {
+	not input.signature_verified
+	msg := "image signature did not verify"
+}`,
+	}
+
+	t.Run("violation reported", func(t *testing.T) {
+		rules, err := engine.evaluateRego(t.Context(), Input{SignatureVerified: false})
+		if err != nil {
+			t.Fatalf("evaluateRego: %v", err)
+		}
+		if len(rules) != 1 || rules[0].Passed {
+			t.Fatalf("rules = %+v, want a single failing rule", rules)
+		}
+	})
+
+	t.Run("no violations", func(t *testing.T) {
+		rules, err := engine.evaluateRego(t.Context(), Input{SignatureVerified: true})
+		if err != nil {
+			t.Fatalf("evaluateRego: %v", err)
+		}
+		if len(rules) != 1 || !rules[0].Passed {
+			t.Fatalf("rules = %+v, want a single passing rule", rules)
+		}
+	})
+}
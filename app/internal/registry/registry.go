@@ -0,0 +1,107 @@
+// Package registry fetches OCI referrer artifacts (e.g. SBOMs attached by
+// syft/cosign as image referrers) from a container registry, for use as a
+// fallback when an SBOM wasn't embedded into the binary at build time.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"container-sbom-signing-attestation/internal/sbom"
+)
+
+// referrerArtifactTypes maps each SBOM format to the OCI artifact type used
+// when syft/cosign attach it as a referrer.
+var referrerArtifactTypes = map[sbom.Format]string{
+	sbom.FormatSPDX:      "application/spdx+json",
+	sbom.FormatCycloneDX: "application/vnd.cyclonedx+json",
+}
+
+// Client fetches referrer artifacts from an OCI registry.
+type Client struct{}
+
+// NewClient returns a registry Client. Construction takes no arguments
+// today; registry auth is resolved per-call from the ambient Docker/OCI
+// credential store, matching how cosign itself authenticates.
+func NewClient() *Client {
+	return &Client{}
+}
+
+// FetchReferrerSBOM fetches the SBOM referrer of the given format attached
+// to imageRef.
+func (c *Client) FetchReferrerSBOM(imageRef string, format sbom.Format) ([]byte, error) {
+	artifactType, ok := referrerArtifactTypes[format]
+	if !ok {
+		return nil, fmt.Errorf("no referrer artifact type known for format %q", format)
+	}
+
+	repo, err := remote.NewRepository(imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("resolving repository for %q: %w", imageRef, err)
+	}
+
+	return fetchReferrerBlob(repo, imageRef, artifactType)
+}
+
+// fetchReferrerBlob resolves imageRef to its own manifest descriptor, walks
+// that manifest's referrers for the given OCI artifact type, and returns the
+// first match's sole layer blob.
+func fetchReferrerBlob(repo *remote.Repository, imageRef, artifactType string) ([]byte, error) {
+	ctx := context.Background()
+
+	subject, err := repo.Resolve(ctx, imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("resolving manifest descriptor for %q: %w", imageRef, err)
+	}
+
+	var match ocispec.Descriptor
+	found := false
+	err = repo.Referrers(ctx, subject, artifactType, func(referrers []ocispec.Descriptor) error {
+		for _, d := range referrers {
+			if d.ArtifactType == artifactType {
+				match = d
+				found = true
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing referrers: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("no referrer found with artifact type %q", artifactType)
+	}
+
+	manifestData, err := content.FetchAll(ctx, repo, match)
+	if err != nil {
+		return nil, fmt.Errorf("fetching referrer manifest: %w", err)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing referrer manifest: %w", err)
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, fmt.Errorf("referrer manifest has no layers")
+	}
+
+	rc, err := repo.Fetch(ctx, manifest.Layers[0])
+	if err != nil {
+		return nil, fmt.Errorf("fetching referrer blob: %w", err)
+	}
+	defer rc.Close()
+
+	buf := new(bytes.Buffer)
+	if _, err := io.Copy(buf, rc); err != nil {
+		return nil, fmt.Errorf("reading referrer blob: %w", err)
+	}
+	return buf.Bytes(), nil
+}
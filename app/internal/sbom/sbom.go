@@ -0,0 +1,219 @@
+// Package sbom serves the SBOM and vulnerability scan results produced for
+// this image's own build. The documents under data/ are placeholders
+// checked in so //go:embed has something to compile against; the real
+// build pipeline runs `syft` and `grype`/`trivy` against the built image
+// and overwrites them before `go build` is invoked, so the binary always
+// embeds the SBOM for the exact artifact it ships in.
+package sbom
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Format identifies one of the SBOM representations this package can serve.
+type Format string
+
+const (
+	FormatSPDX       Format = "application/spdx+json"
+	FormatCycloneDX  Format = "application/vnd.cyclonedx+json"
+	FormatInTotoAttestation Format = "application/vnd.in-toto+json"
+)
+
+//go:embed data/sbom.spdx.json
+var spdxDoc []byte
+
+//go:embed data/sbom.cyclonedx.json
+var cyclonedxDoc []byte
+
+//go:embed data/vulnerabilities.json
+var vulnReportRaw []byte
+
+// VulnerabilitySummary is the severity breakdown from the embedded
+// scan, surfaced both via /sbom/vulnerabilities and via /health.
+type VulnerabilitySummary struct {
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+	Unknown  int `json:"unknown"`
+}
+
+type vulnReport struct {
+	Scanner   string                `json:"scanner"`
+	ScannedAt string                `json:"scanned_at"`
+	Summary   VulnerabilitySummary  `json:"summary"`
+	Matches   json.RawMessage       `json:"matches"`
+}
+
+// Registry is the narrow interface sbom needs from an OCI client to fall
+// back to fetching a referrer-attached SBOM when the embedded placeholder
+// wasn't replaced at build time (e.g. running the binary outside its CI
+// pipeline). Implemented by internal/registry in production and faked in
+// tests.
+type Registry interface {
+	FetchReferrerSBOM(imageRef string, format Format) ([]byte, error)
+}
+
+// Provider serves the embedded SBOM/vulnerability documents, or fetches
+// them from an OCI registry referrer when configured and the embedded
+// document looks like the unreplaced placeholder.
+type Provider struct {
+	imageRef string
+	registry Registry
+}
+
+// NewProvider builds a Provider. registry may be nil, in which case the
+// registry fallback is disabled and only the embedded documents are served.
+func NewProvider(imageRef string, registry Registry) *Provider {
+	return &Provider{imageRef: imageRef, registry: registry}
+}
+
+// Negotiate picks the best SBOM representation for the given Accept header
+// and returns its bytes and content type. Unrecognized or empty Accept
+// headers default to SPDX.
+func (p *Provider) Negotiate(accept string, imageDigest string) (Format, []byte, error) {
+	format := p.pickFormat(accept)
+
+	switch format {
+	case FormatCycloneDX:
+		doc, err := p.document(FormatCycloneDX)
+		return format, doc, err
+	case FormatInTotoAttestation:
+		spdx, err := p.document(FormatSPDX)
+		if err != nil {
+			return format, nil, err
+		}
+		att, err := wrapAsInTotoAttestation(spdx, imageDigest)
+		return format, att, err
+	default:
+		doc, err := p.document(FormatSPDX)
+		return FormatSPDX, doc, err
+	}
+}
+
+func (p *Provider) pickFormat(accept string) Format {
+	for _, part := range strings.Split(accept, ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch Format(mt) {
+		case FormatCycloneDX, FormatInTotoAttestation, FormatSPDX:
+			return Format(mt)
+		}
+	}
+	return FormatSPDX
+}
+
+// document returns the embedded bytes for format, falling back to the OCI
+// registry referrer when the embedded copy is the build-time placeholder.
+func (p *Provider) document(format Format) ([]byte, error) {
+	var embedded []byte
+	switch format {
+	case FormatSPDX:
+		embedded = spdxDoc
+	case FormatCycloneDX:
+		embedded = cyclonedxDoc
+	default:
+		return nil, fmt.Errorf("unsupported sbom format %q", format)
+	}
+
+	if !isPlaceholder(embedded) {
+		return embedded, nil
+	}
+	if p.registry == nil || p.imageRef == "" {
+		return embedded, nil
+	}
+	fetched, err := p.registry.FetchReferrerSBOM(p.imageRef, format)
+	if err != nil {
+		return embedded, fmt.Errorf("embedded sbom is a placeholder and registry fallback failed: %w", err)
+	}
+	return fetched, nil
+}
+
+func isPlaceholder(doc []byte) bool {
+	return strings.Contains(string(doc), "placeholder")
+}
+
+// wrapAsInTotoAttestation wraps an SBOM document as an in-toto statement
+// with the running image's digest as its subject, per the in-toto
+// attestation spec's predicate envelope shape.
+func wrapAsInTotoAttestation(sbomDoc []byte, imageDigest string) ([]byte, error) {
+	var predicate json.RawMessage = sbomDoc
+	statement := map[string]interface{}{
+		"_type":         "https://in-toto.io/Statement/v1",
+		"predicateType": "https://spdx.dev/Document",
+		"subject": []map[string]interface{}{
+			{
+				"name":   imageDigest,
+				"digest": map[string]string{"sha256": strings.TrimPrefix(imageDigest, "sha256:")},
+			},
+		},
+		"predicate": predicate,
+	}
+	return json.Marshal(statement)
+}
+
+// Vulnerabilities returns the embedded vulnerability scan summary and raw
+// match list.
+func (p *Provider) Vulnerabilities() (VulnerabilitySummary, json.RawMessage, error) {
+	var report vulnReport
+	if err := json.Unmarshal(vulnReportRaw, &report); err != nil {
+		return VulnerabilitySummary{}, nil, fmt.Errorf("parsing embedded vulnerability report: %w", err)
+	}
+	return report.Summary, report.Matches, nil
+}
+
+type spdxPackage struct {
+	ExternalRefs []struct {
+		ReferenceCategory string `json:"referenceCategory"`
+		ReferenceType     string `json:"referenceType"`
+		ReferenceLocator  string `json:"referenceLocator"`
+	} `json:"externalRefs"`
+}
+
+type spdxDocument struct {
+	Packages []spdxPackage `json:"packages"`
+}
+
+// ComponentCountsByEcosystem returns the number of SBOM components per
+// package ecosystem (npm, pypi, golang, ...), derived from each package's
+// purl external reference.
+func (p *Provider) ComponentCountsByEcosystem() (map[string]int, error) {
+	var doc spdxDocument
+	if err := json.Unmarshal(spdxDoc, &doc); err != nil {
+		return nil, fmt.Errorf("parsing embedded spdx document: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, pkg := range doc.Packages {
+		for _, ref := range pkg.ExternalRefs {
+			if ref.ReferenceType != "purl" {
+				continue
+			}
+			ecosystem := ecosystemFromPurl(ref.ReferenceLocator)
+			if ecosystem != "" {
+				counts[ecosystem]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// ecosystemFromPurl extracts the package type from a purl, e.g.
+// "pkg:npm/left-pad@1.3.0" -> "npm".
+func ecosystemFromPurl(purl string) string {
+	const prefix = "pkg:"
+	if !strings.HasPrefix(purl, prefix) {
+		return ""
+	}
+	rest := purl[len(prefix):]
+	if idx := strings.IndexByte(rest, '/'); idx >= 0 {
+		return rest[:idx]
+	}
+	return ""
+}
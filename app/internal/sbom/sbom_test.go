@@ -0,0 +1,50 @@
+package sbom
+
+import "testing"
+
+func TestEcosystemFromPurl(t *testing.T) {
+	cases := []struct {
+		purl string
+		want string
+	}{
+		{"pkg:npm/left-pad@1.3.0", "npm"},
+		{"pkg:golang/github.com/foo/bar@v1.2.3", "golang"},
+		{"pkg:pypi/requests@2.31.0", "pypi"},
+		{"not-a-purl", ""},
+		{"pkg:", ""},
+	}
+	for _, c := range cases {
+		if got := ecosystemFromPurl(c.purl); got != c.want {
+			t.Errorf("ecosystemFromPurl(%q) = %q, want %q", c.purl, got, c.want)
+		}
+	}
+}
+
+func TestProviderPickFormat(t *testing.T) {
+	p := NewProvider("", nil)
+	cases := []struct {
+		accept string
+		want   Format
+	}{
+		{"", FormatSPDX},
+		{"application/spdx+json", FormatSPDX},
+		{"application/vnd.cyclonedx+json", FormatCycloneDX},
+		{"application/vnd.in-toto+json", FormatInTotoAttestation},
+		{"text/html, application/vnd.cyclonedx+json;q=0.9", FormatCycloneDX},
+		{"text/html", FormatSPDX},
+	}
+	for _, c := range cases {
+		if got := p.pickFormat(c.accept); got != c.want {
+			t.Errorf("pickFormat(%q) = %q, want %q", c.accept, got, c.want)
+		}
+	}
+}
+
+func TestIsPlaceholder(t *testing.T) {
+	if !isPlaceholder([]byte(`{"note":"placeholder"}`)) {
+		t.Error("expected placeholder doc to be detected")
+	}
+	if isPlaceholder([]byte(`{"packages":[]}`)) {
+		t.Error("did not expect a real doc to be detected as a placeholder")
+	}
+}
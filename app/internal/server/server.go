@@ -0,0 +1,244 @@
+// Package server wires the HTTP routes exposed by the supply-chain demo
+// app: health, transparency-log status, and SBOM/vulnerability serving.
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+
+	"container-sbom-signing-attestation/internal/metrics"
+	"container-sbom-signing-attestation/internal/policy"
+	"container-sbom-signing-attestation/internal/sbom"
+	"container-sbom-signing-attestation/internal/transparency"
+)
+
+// BuildInfo is the version metadata baked in at build time.
+type BuildInfo struct {
+	Version   string
+	BuildTime string
+	GitCommit string
+}
+
+// Attestation is one verified in-toto attestation found for the image,
+// e.g. SLSA provenance or an SPDX/CycloneDX SBOM attestation.
+type Attestation struct {
+	PredicateType string
+	SBOMDigest    string
+	BuilderID     string
+	SignedAt      time.Time
+}
+
+// VerificationStatus is the cached result of verifying this image's Cosign
+// signature and attestations, as computed at startup.
+type VerificationStatus struct {
+	ImageDigest       string
+	SignatureVerified bool
+	Attestations      []Attestation
+	RekorLogIndex     int64
+	SignerIssuer      string
+	SignerIdentity    string
+	Errors            []string
+	Strict            bool
+}
+
+// Server holds the dependencies needed to answer HTTP requests and exposes
+// them as a *http.ServeMux.
+type Server struct {
+	build        BuildInfo
+	verification VerificationStatus
+	transparency *transparency.Client
+	sbom         *sbom.Provider
+	metrics      *metrics.Metrics
+	policy       *policy.Result
+}
+
+// New builds a Server. transparencyClient may be nil if transparency
+// tracking is disabled, and policyResult may be nil if no policy was
+// configured.
+func New(build BuildInfo, verification VerificationStatus, transparencyClient *transparency.Client, sbomProvider *sbom.Provider, appMetrics *metrics.Metrics, policyResult *policy.Result) *Server {
+	return &Server{
+		build:        build,
+		verification: verification,
+		transparency: transparencyClient,
+		sbom:         sbomProvider,
+		metrics:      appMetrics,
+		policy:       policyResult,
+	}
+}
+
+// RefreshMetrics pushes the current verification, transparency, and SBOM
+// state into the Prometheus gauges. It is safe to call repeatedly; main
+// calls it once at startup and again from the transparency client's
+// OnUpdate hook every time that client re-verifies, so the gauges never go
+// stale between polls.
+func (s *Server) RefreshMetrics() {
+	if s.metrics == nil {
+		return
+	}
+
+	v := s.verification
+	s.metrics.SetSignatureVerified(v.SignerIssuer, v.SignerIdentity, v.SignatureVerified)
+	now := time.Now().UTC()
+	for _, a := range v.Attestations {
+		if !a.SignedAt.IsZero() {
+			s.metrics.SetAttestationAge(a.PredicateType, a.SignedAt, now)
+		}
+	}
+
+	if s.sbom != nil {
+		if counts, err := s.sbom.ComponentCountsByEcosystem(); err == nil {
+			s.metrics.SetSBOMComponentCounts(counts)
+		}
+		if summary, _, err := s.sbom.Vulnerabilities(); err == nil {
+			s.metrics.SetKnownVulnerabilities(summary.Critical, summary.High, summary.Medium, summary.Low, summary.Unknown)
+		}
+	}
+
+	if s.transparency != nil {
+		s.metrics.SetRekorInclusionVerified(s.transparency.Status().InclusionOK)
+	}
+}
+
+// Routes returns the ServeMux with every handler registered.
+func (s *Server) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/transparency", s.handleTransparency)
+	mux.HandleFunc("/sbom", s.handleSBOM)
+	mux.HandleFunc("/sbom/vulnerabilities", s.handleVulnerabilities)
+	mux.HandleFunc("/policy", s.handlePolicy)
+	if s.metrics != nil {
+		mux.Handle("/metrics", s.metrics.Handler())
+	}
+	mux.HandleFunc("/", s.handleHealth)
+	return mux
+}
+
+type healthAttestation struct {
+	PredicateType string `json:"predicate_type"`
+	SBOMDigest    string `json:"sbom_digest,omitempty"`
+	BuilderID     string `json:"builder_id,omitempty"`
+}
+
+type healthResponse struct {
+	Status             string                     `json:"status"`
+	Version            string                     `json:"version"`
+	BuildTime          string                     `json:"build_time"`
+	GitCommit          string                     `json:"git_commit"`
+	GoVersion          string                     `json:"go_version"`
+	Timestamp          string                     `json:"timestamp"`
+	ImageDigest        string                     `json:"image_digest,omitempty"`
+	SignatureVerified  bool                       `json:"signature_verified"`
+	Attestations       []healthAttestation        `json:"attestations,omitempty"`
+	RekorLogIndex      int64                      `json:"rekor_log_index,omitempty"`
+	VerificationErrors []string                   `json:"verification_errors,omitempty"`
+	Vulnerabilities    *sbom.VulnerabilitySummary `json:"vulnerabilities,omitempty"`
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	v := s.verification
+	status := "healthy"
+	httpStatus := http.StatusOK
+	if v.Strict && (len(v.Errors) > 0 || !v.SignatureVerified) {
+		status = "unhealthy"
+		httpStatus = http.StatusServiceUnavailable
+	}
+	if s.policy != nil && !s.policy.Passed && s.policy.Mode == policy.ModeWarn && status == "healthy" {
+		status = "degraded"
+	}
+
+	resp := healthResponse{
+		Status:             status,
+		Version:            s.build.Version,
+		BuildTime:          s.build.BuildTime,
+		GitCommit:          s.build.GitCommit,
+		GoVersion:          runtime.Version(),
+		Timestamp:          time.Now().UTC().Format(time.RFC3339),
+		ImageDigest:        v.ImageDigest,
+		SignatureVerified:  v.SignatureVerified,
+		RekorLogIndex:      v.RekorLogIndex,
+		VerificationErrors: v.Errors,
+	}
+	for _, a := range v.Attestations {
+		resp.Attestations = append(resp.Attestations, healthAttestation{
+			PredicateType: a.PredicateType,
+			SBOMDigest:    a.SBOMDigest,
+			BuilderID:     a.BuilderID,
+		})
+	}
+
+	if s.sbom != nil {
+		if summary, _, err := s.sbom.Vulnerabilities(); err == nil {
+			resp.Vulnerabilities = &summary
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleTransparency(w http.ResponseWriter, r *http.Request) {
+	if s.transparency == nil {
+		http.Error(w, `{"error":"transparency tracking not configured"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	status := s.transparency.Status()
+	w.Header().Set("Content-Type", "application/json")
+	if !status.InclusionOK || status.Stale {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) handleSBOM(w http.ResponseWriter, r *http.Request) {
+	if s.sbom == nil {
+		http.Error(w, `{"error":"sbom not configured"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	format, doc, err := s.sbom.Negotiate(r.Header.Get("Accept"), s.verification.ImageDigest)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", string(format))
+	w.Write(doc)
+}
+
+func (s *Server) handlePolicy(w http.ResponseWriter, r *http.Request) {
+	if s.policy == nil {
+		http.Error(w, `{"error":"no policy configured"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.policy)
+}
+
+type vulnerabilitiesResponse struct {
+	Summary sbom.VulnerabilitySummary `json:"summary"`
+	Matches json.RawMessage           `json:"matches"`
+}
+
+func (s *Server) handleVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	if s.sbom == nil {
+		http.Error(w, `{"error":"sbom not configured"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	summary, matches, err := s.sbom.Vulnerabilities()
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vulnerabilitiesResponse{Summary: summary, Matches: matches})
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"container-sbom-signing-attestation/internal/policy"
+	"container-sbom-signing-attestation/internal/sbom"
+)
+
+func TestHandleHealth(t *testing.T) {
+	t.Run("healthy", func(t *testing.T) {
+		s := New(BuildInfo{Version: "v1"}, VerificationStatus{SignatureVerified: true}, nil, nil, nil, nil)
+
+		rr := httptest.NewRecorder()
+		s.handleHealth(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+		}
+		var resp healthResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if resp.Status != "healthy" {
+			t.Errorf("status field = %q, want %q", resp.Status, "healthy")
+		}
+	})
+
+	t.Run("strict mode with verification errors returns 503", func(t *testing.T) {
+		v := VerificationStatus{
+			Strict:            true,
+			SignatureVerified: false,
+			Errors:            []string{"signature did not verify"},
+		}
+		s := New(BuildInfo{}, v, nil, nil, nil, nil)
+
+		rr := httptest.NewRecorder()
+		s.handleHealth(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+		}
+		var resp healthResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if resp.Status != "unhealthy" {
+			t.Errorf("status field = %q, want %q", resp.Status, "unhealthy")
+		}
+	})
+
+	t.Run("non-strict mode tolerates verification errors", func(t *testing.T) {
+		v := VerificationStatus{
+			Strict:            false,
+			SignatureVerified: false,
+			Errors:            []string{"signature did not verify"},
+		}
+		s := New(BuildInfo{}, v, nil, nil, nil, nil)
+
+		rr := httptest.NewRecorder()
+		s.handleHealth(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("warn-mode policy failure reports degraded", func(t *testing.T) {
+		v := VerificationStatus{SignatureVerified: true}
+		result := &policy.Result{Passed: false, Mode: policy.ModeWarn}
+		s := New(BuildInfo{}, v, nil, nil, nil, result)
+
+		rr := httptest.NewRecorder()
+		s.handleHealth(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+		}
+		var resp healthResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if resp.Status != "degraded" {
+			t.Errorf("status field = %q, want %q", resp.Status, "degraded")
+		}
+	})
+}
+
+func TestHandleSBOM(t *testing.T) {
+	s := New(BuildInfo{}, VerificationStatus{}, nil, sbom.NewProvider("", nil), nil, nil)
+
+	cases := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"default", "", string(sbom.FormatSPDX)},
+		{"spdx", "application/spdx+json", string(sbom.FormatSPDX)},
+		{"cyclonedx", "application/vnd.cyclonedx+json", string(sbom.FormatCycloneDX)},
+		{"in-toto attestation", "application/vnd.in-toto+json", string(sbom.FormatInTotoAttestation)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/sbom", nil)
+			if c.accept != "" {
+				req.Header.Set("Accept", c.accept)
+			}
+			rr := httptest.NewRecorder()
+			s.handleSBOM(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			if got := rr.Header().Get("Content-Type"); got != c.want {
+				t.Errorf("Content-Type = %q, want %q", got, c.want)
+			}
+			if rr.Body.Len() == 0 {
+				t.Error("expected a non-empty SBOM document body")
+			}
+		})
+	}
+
+	t.Run("no provider configured", func(t *testing.T) {
+		s := New(BuildInfo{}, VerificationStatus{}, nil, nil, nil, nil)
+		rr := httptest.NewRecorder()
+		s.handleSBOM(rr, httptest.NewRequest(http.MethodGet, "/sbom", nil))
+
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -0,0 +1,499 @@
+// Package transparency verifies that the signature entry for the running
+// container image is included in the configured Rekor transparency log, and
+// that the log's signed tree head is append-only across observations.
+//
+// The verification follows the RFC 6962 Merkle inclusion proof shape used by
+// Certificate Transparency and adopted by Rekor/sigsum-style logs: a leaf
+// hash plus an audit path is recomputed up to a root hash, which must match
+// the root committed to by a signed tree head (STH) for some tree size. The
+// last-seen STH is persisted to disk so that a log that forks or serves a
+// split view (a different root for a tree size we've already observed) is
+// detected rather than silently accepted.
+package transparency
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+// Config configures the transparency-log client.
+type Config struct {
+	// RekorURL is the base URL of the Rekor instance to query.
+	RekorURL string
+	// LogPublicKey is the PEM or base64-encoded ed25519 public key used to
+	// verify signed tree heads from RekorURL.
+	LogPublicKey string
+	// EntryUUID is the Rekor UUID of the signature entry to track.
+	EntryUUID string
+	// StateFile is where the last-seen signed tree head is persisted
+	// between restarts, to detect log forks.
+	StateFile string
+	// PollInterval is how often to re-fetch and re-verify the inclusion
+	// proof. Zero disables periodic polling.
+	PollInterval time.Duration
+	// MaxStaleness is the longest a successful verification may be trusted
+	// for before Status.Stale is reported.
+	MaxStaleness time.Duration
+}
+
+// SignedTreeHead is a Rekor/CT-style commitment to the state of the log at
+// a given size.
+type SignedTreeHead struct {
+	Origin    string    `json:"origin"`
+	TreeSize  int64     `json:"tree_size"`
+	RootHash  []byte    `json:"root_hash"`
+	Timestamp time.Time `json:"timestamp"`
+	Signature []byte    `json:"signature"`
+}
+
+// InclusionProof is the audit path proving a leaf is included in the tree
+// committed to by an STH.
+type InclusionProof struct {
+	LeafHash  []byte
+	LeafIndex int64
+	AuditPath [][]byte
+	STH       SignedTreeHead
+}
+
+// Status is the latest verified state, served via the /transparency
+// endpoint.
+type Status struct {
+	TreeSize     int64     `json:"tree_size"`
+	RootHash     string    `json:"root_hash"`
+	LastVerified time.Time `json:"last_verified"`
+	InclusionOK  bool      `json:"inclusion_verified"`
+	Stale        bool      `json:"stale"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Client fetches and verifies Rekor inclusion proofs on a schedule and
+// caches the latest verified Status.
+type Client struct {
+	cfg       Config
+	logPubKey ed25519.PublicKey
+	http      *http.Client
+
+	mu       sync.RWMutex
+	status   Status
+	lastSTH  *SignedTreeHead
+	onUpdate func(Status)
+}
+
+// OnUpdate registers fn to be called after every refresh (the initial one
+// performed by Start and every subsequent poll), with the Status that was
+// just computed. Callers use this to keep metrics or other derived state in
+// sync with the transparency client instead of only reading it once at
+// startup. Must be called before Start.
+func (c *Client) OnUpdate(fn func(Status)) {
+	c.mu.Lock()
+	c.onUpdate = fn
+	c.mu.Unlock()
+}
+
+// NewClient builds a Client, parsing the pinned log public key. It does not
+// perform any network I/O.
+func NewClient(cfg Config) (*Client, error) {
+	key, err := parseEd25519PublicKey(cfg.LogPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("parsing log public key: %w", err)
+	}
+	return &Client{
+		cfg:       cfg,
+		logPubKey: key,
+		http:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Status returns the most recently verified state.
+func (c *Client) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.status
+}
+
+// Start performs an initial verification and then, if cfg.PollInterval is
+// set, re-verifies on a ticker until stop is closed.
+func (c *Client) Start(stop <-chan struct{}) {
+	c.refresh()
+	if c.cfg.PollInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(c.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.refresh()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *Client) refresh() {
+	proof, err := c.fetchInclusionProof()
+	if err != nil {
+		c.setStatus(Status{Error: err.Error()})
+		return
+	}
+
+	if err := verifySTHSignature(proof.STH, c.logPubKey); err != nil {
+		c.setStatus(Status{Error: fmt.Sprintf("signed tree head signature invalid: %v", err)})
+		return
+	}
+
+	if err := c.checkConsistency(proof.STH); err != nil {
+		c.setStatus(Status{Error: fmt.Sprintf("log consistency check failed: %v", err)})
+		return
+	}
+
+	root := computeInclusionRoot(proof.LeafHash, proof.LeafIndex, proof.STH.TreeSize, proof.AuditPath)
+	if !bytesEqual(root, proof.STH.RootHash) {
+		c.setStatus(Status{Error: "recomputed root does not match signed tree head"})
+		return
+	}
+
+	if err := c.persistSTH(proof.STH); err != nil {
+		// Persistence failures shouldn't mask a successful verification,
+		// but they do mean the next restart can't detect a fork.
+		c.mu.Lock()
+		c.lastSTH = &proof.STH
+		c.mu.Unlock()
+		c.setStatus(Status{
+			TreeSize:     proof.STH.TreeSize,
+			RootHash:     base64.StdEncoding.EncodeToString(proof.STH.RootHash),
+			LastVerified: time.Now().UTC(),
+			InclusionOK:  true,
+			Error:        fmt.Sprintf("verified but failed to persist STH: %v", err),
+		})
+		return
+	}
+
+	c.setStatus(Status{
+		TreeSize:     proof.STH.TreeSize,
+		RootHash:     base64.StdEncoding.EncodeToString(proof.STH.RootHash),
+		LastVerified: time.Now().UTC(),
+		InclusionOK:  true,
+	})
+}
+
+func (c *Client) setStatus(s Status) {
+	if s.LastVerified.IsZero() {
+		c.mu.RLock()
+		s.LastVerified = c.status.LastVerified
+		c.mu.RUnlock()
+	}
+	if c.cfg.MaxStaleness > 0 && time.Since(s.LastVerified) > c.cfg.MaxStaleness {
+		s.Stale = true
+	}
+	c.mu.Lock()
+	c.status = s
+	onUpdate := c.onUpdate
+	c.mu.Unlock()
+	if onUpdate != nil {
+		onUpdate(s)
+	}
+}
+
+// checkConsistency compares sth against the last STH seen on disk (if any)
+// and rejects any tree size we've seen before with a different root hash,
+// which would indicate a forked or split-view log.
+func (c *Client) checkConsistency(sth SignedTreeHead) error {
+	c.mu.RLock()
+	last := c.lastSTH
+	c.mu.RUnlock()
+	if last == nil {
+		loaded, err := c.loadSTH()
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		last = loaded
+	}
+	if last == nil {
+		return nil
+	}
+	if last.TreeSize == sth.TreeSize && !bytesEqual(last.RootHash, sth.RootHash) {
+		return fmt.Errorf("tree size %d previously had root %x, now observed %x", sth.TreeSize, last.RootHash, sth.RootHash)
+	}
+	if sth.TreeSize < last.TreeSize {
+		return fmt.Errorf("observed tree size %d is smaller than last-seen size %d", sth.TreeSize, last.TreeSize)
+	}
+	return nil
+}
+
+func (c *Client) loadSTH() (*SignedTreeHead, error) {
+	if c.cfg.StateFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(c.cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	var sth SignedTreeHead
+	if err := json.Unmarshal(data, &sth); err != nil {
+		return nil, fmt.Errorf("parsing persisted signed tree head: %w", err)
+	}
+	return &sth, nil
+}
+
+func (c *Client) persistSTH(sth SignedTreeHead) error {
+	c.mu.Lock()
+	c.lastSTH = &sth
+	c.mu.Unlock()
+
+	if c.cfg.StateFile == "" {
+		return nil
+	}
+	data, err := json.Marshal(sth)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.cfg.StateFile), 0o755); err != nil {
+		return err
+	}
+	tmp := c.cfg.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.cfg.StateFile)
+}
+
+// fetchInclusionProof calls Rekor's GET /api/v1/log/entries/{uuid} for
+// cfg.EntryUUID. The response is a LogEntry: a JSON object keyed by the
+// entry's UUID, mapping to a single LogEntryAnon carrying the entry body
+// and its verification.inclusionProof.
+func (c *Client) fetchInclusionProof() (*InclusionProof, error) {
+	url := fmt.Sprintf("%s/api/v1/log/entries/%s", c.cfg.RekorURL, c.cfg.EntryUUID)
+	resp, err := c.http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching rekor entry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rekor entry fetch: unexpected status %d", resp.StatusCode)
+	}
+
+	var entries models.LogEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decoding rekor entry: %w", err)
+	}
+	if len(entries) != 1 {
+		return nil, fmt.Errorf("rekor entry response has %d entries, want 1 for uuid %q", len(entries), c.cfg.EntryUUID)
+	}
+	var entry models.LogEntryAnon
+	for _, e := range entries {
+		entry = e
+	}
+	if entry.Verification == nil || entry.Verification.InclusionProof == nil {
+		return nil, fmt.Errorf("rekor entry %q has no inclusion proof", c.cfg.EntryUUID)
+	}
+	proof := entry.Verification.InclusionProof
+
+	auditPath := make([][]byte, 0, len(proof.Hashes))
+	for _, h := range proof.Hashes {
+		b, err := decodeHex(h)
+		if err != nil {
+			return nil, fmt.Errorf("decoding audit path hash: %w", err)
+		}
+		auditPath = append(auditPath, b)
+	}
+	if proof.RootHash == nil || proof.TreeSize == nil || proof.LogIndex == nil || proof.Checkpoint == nil {
+		return nil, fmt.Errorf("rekor entry %q has an incomplete inclusion proof", c.cfg.EntryUUID)
+	}
+	rootHash, err := decodeHex(*proof.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("decoding root hash: %w", err)
+	}
+	sth, err := parseCheckpoint(*proof.Checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signed checkpoint: %w", err)
+	}
+	// The unsigned inclusionProof JSON fields must agree with the checkpoint
+	// body that was actually signature-checked; otherwise a log could report
+	// one root in the proof and a different (stale or forked) one in the
+	// checkpoint and we'd verify the wrong thing.
+	if sth.TreeSize != *proof.TreeSize {
+		return nil, fmt.Errorf("inclusion proof tree size %d does not match checkpoint tree size %d", *proof.TreeSize, sth.TreeSize)
+	}
+	if !bytesEqual(sth.RootHash, rootHash) {
+		return nil, fmt.Errorf("inclusion proof root hash does not match checkpoint root hash")
+	}
+
+	leafHash, err := leafHashFromEntry(entry.Body)
+	if err != nil {
+		return nil, fmt.Errorf("computing leaf hash: %w", err)
+	}
+
+	return &InclusionProof{
+		LeafHash:  leafHash,
+		LeafIndex: *proof.LogIndex,
+		AuditPath: auditPath,
+		STH:       sth,
+	}, nil
+}
+
+// leafHashFromEntry computes the RFC 6962 leaf hash (0x00 prefix) for a log
+// entry, over the base64-decoded canonical entry body rather than the
+// surrounding JSON envelope — the same bytes the log itself hashed when it
+// built the Merkle tree, per Rekor's own pkg/verify.VerifyInclusion.
+func leafHashFromEntry(body interface{}) ([]byte, error) {
+	encoded, ok := body.(string)
+	if !ok {
+		return nil, fmt.Errorf("entry body is %T, want base64 string", body)
+	}
+	entryBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding entry body: %w", err)
+	}
+	h := sha256.Sum256(append([]byte{0x00}, entryBytes...))
+	return h[:], nil
+}
+
+// computeInclusionRoot recomputes the RFC 6962 Merkle root from a leaf hash
+// at leafIndex in a tree of treeSize, given its audit path.
+func computeInclusionRoot(leafHash []byte, leafIndex, treeSize int64, auditPath [][]byte) []byte {
+	node := leafHash
+	fn, sn := leafIndex, treeSize-1
+	for _, sibling := range auditPath {
+		if fn&1 == 1 || fn == sn {
+			node = hashChildren(sibling, node)
+			for fn&1 == 0 && fn != sn {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			node = hashChildren(node, sibling)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return node
+}
+
+// hashChildren computes the RFC 6962 interior-node hash (0x01 prefix) for a
+// left/right pair of child hashes.
+func hashChildren(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// verifySTHSignature checks the ed25519 signature over a signed tree head
+// against the pinned log public key.
+func verifySTHSignature(sth SignedTreeHead, pub ed25519.PublicKey) error {
+	msg := sthSignedMessage(sth)
+	if !ed25519.Verify(pub, msg, sth.Signature) {
+		return fmt.Errorf("invalid signature for tree size %d", sth.TreeSize)
+	}
+	return nil
+}
+
+// sthSignedMessage reconstructs the exact checkpoint body bytes that were
+// signed: "<origin>\n<size>\n<root-b64>\n", per the sigsum/STFE checkpoint
+// format parseCheckpoint reads.
+func sthSignedMessage(sth SignedTreeHead) []byte {
+	msg := fmt.Sprintf("%s\n%d\n%s\n", sth.Origin, sth.TreeSize, base64.StdEncoding.EncodeToString(sth.RootHash))
+	return []byte(msg)
+}
+
+func parseEd25519PublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding base64 public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("expected %d byte ed25519 key, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// parseCheckpoint parses a sigsum/STFE-style signed checkpoint of the form
+// "<origin>\n<size>\n<root-b64>\n\n— <keyname> <signature-b64>\n" into an
+// STH whose TreeSize/RootHash/Signature come entirely from the checkpoint
+// body itself, so the caller verifies the signature against exactly the
+// size/root it was computed over rather than values sourced elsewhere.
+func parseCheckpoint(checkpoint string) (SignedTreeHead, error) {
+	if checkpoint == "" {
+		return SignedTreeHead{}, fmt.Errorf("empty checkpoint")
+	}
+
+	sigMarker := "— "
+	idx := strings.LastIndex(checkpoint, sigMarker)
+	if idx < 0 {
+		return SignedTreeHead{}, fmt.Errorf("no signature line in checkpoint")
+	}
+	body := checkpoint[:idx]
+	sigLine := checkpoint[idx+len(sigMarker):]
+
+	bodyLines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	if len(bodyLines) < 3 {
+		return SignedTreeHead{}, fmt.Errorf("checkpoint body has %d lines, want at least 3 (origin, size, root)", len(bodyLines))
+	}
+	treeSize, err := strconv.ParseInt(bodyLines[1], 10, 64)
+	if err != nil {
+		return SignedTreeHead{}, fmt.Errorf("parsing checkpoint tree size: %w", err)
+	}
+	rootHash, err := base64.StdEncoding.DecodeString(bodyLines[2])
+	if err != nil {
+		return SignedTreeHead{}, fmt.Errorf("decoding checkpoint root hash: %w", err)
+	}
+
+	fields := strings.Fields(sigLine)
+	if len(fields) < 2 {
+		return SignedTreeHead{}, fmt.Errorf("malformed signature line")
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return SignedTreeHead{}, fmt.Errorf("decoding signature: %w", err)
+	}
+	// Per the note/checkpoint format (golang.org/x/mod/sumdb/note, and
+	// Rekor's own pkg/util/signed_note.go), the decoded blob is a 4-byte
+	// big-endian key-hash hint followed by the raw signature.
+	if len(sigBytes) < 5 {
+		return SignedTreeHead{}, fmt.Errorf("signature blob too short: got %d bytes, want at least 5", len(sigBytes))
+	}
+	sig := sigBytes[4:]
+
+	return SignedTreeHead{
+		Origin:    bodyLines[0],
+		TreeSize:  treeSize,
+		RootHash:  rootHash,
+		Timestamp: time.Now().UTC(),
+		Signature: sig,
+	}, nil
+}
+
+func bytesEqual(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeHex(s string) ([]byte, error) {
+	out := make([]byte, len(s)/2)
+	_, err := fmt.Sscanf(s, "%x", &out)
+	return out, err
+}
+
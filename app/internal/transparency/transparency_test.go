@@ -0,0 +1,183 @@
+package transparency
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+)
+
+func leaf(b byte) []byte {
+	h := sha256.Sum256([]byte{0x00, b})
+	return h[:]
+}
+
+func TestComputeInclusionRoot(t *testing.T) {
+	l0, l1, l2 := leaf('a'), leaf('b'), leaf('c')
+
+	t.Run("single leaf tree", func(t *testing.T) {
+		root := computeInclusionRoot(l0, 0, 1, nil)
+		if !bytes.Equal(root, l0) {
+			t.Fatalf("root = %x, want leaf hash %x", root, l0)
+		}
+	})
+
+	t.Run("two leaf tree, first leaf", func(t *testing.T) {
+		want := hashChildren(l0, l1)
+		got := computeInclusionRoot(l0, 0, 2, [][]byte{l1})
+		if !bytes.Equal(got, want) {
+			t.Fatalf("root = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("two leaf tree, second leaf", func(t *testing.T) {
+		want := hashChildren(l0, l1)
+		got := computeInclusionRoot(l1, 1, 2, [][]byte{l0})
+		if !bytes.Equal(got, want) {
+			t.Fatalf("root = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("three leaf tree, third leaf", func(t *testing.T) {
+		// RFC 6962 three-leaf tree: root = hash(hash(l0,l1), l2).
+		want := hashChildren(hashChildren(l0, l1), l2)
+		got := computeInclusionRoot(l2, 2, 3, [][]byte{hashChildren(l0, l1)})
+		if !bytes.Equal(got, want) {
+			t.Fatalf("root = %x, want %x", got, want)
+		}
+	})
+
+	t.Run("wrong audit path does not reproduce root", func(t *testing.T) {
+		want := hashChildren(l0, l1)
+		got := computeInclusionRoot(l0, 0, 2, [][]byte{l2})
+		if bytes.Equal(got, want) {
+			t.Fatalf("root unexpectedly matched with a tampered audit path")
+		}
+	})
+}
+
+func checkpointBody(origin string, size int64, root []byte) string {
+	return origin + "\n" + itoa(size) + "\n" + base64.StdEncoding.EncodeToString(root) + "\n"
+}
+
+func itoa(n int64) string {
+	if n == 0 {
+		return "0"
+	}
+	var digits []byte
+	for n > 0 {
+		digits = append([]byte{byte('0' + n%10)}, digits...)
+		n /= 10
+	}
+	return string(digits)
+}
+
+// signCheckpoint signs body and encodes the signature line in the real
+// note/checkpoint shape used by Rekor and golang.org/x/mod/sumdb/note: a
+// 4-byte key-hash hint followed by the raw signature, both base64-encoded
+// together as a single blob.
+func signCheckpoint(t *testing.T, priv ed25519.PrivateKey, body string, keyname string) string {
+	t.Helper()
+	sig := ed25519.Sign(priv, []byte(body))
+	hint := []byte{0xde, 0xad, 0xbe, 0xef}
+	blob := append(append([]byte{}, hint...), sig...)
+	return body + "\n— " + keyname + " " + base64.StdEncoding.EncodeToString(blob) + "\n"
+}
+
+func TestParseCheckpoint(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+	root := leaf('r')
+	body := checkpointBody("example.com/log", 42, root)
+	checkpoint := signCheckpoint(t, priv, body, "example.com/log")
+
+	sth, err := parseCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatalf("parseCheckpoint: %v", err)
+	}
+	if sth.TreeSize != 42 {
+		t.Errorf("TreeSize = %d, want 42", sth.TreeSize)
+	}
+	if !bytes.Equal(sth.RootHash, root) {
+		t.Errorf("RootHash = %x, want %x", sth.RootHash, root)
+	}
+	if !ed25519.Verify(priv.Public().(ed25519.PublicKey), []byte(body), sth.Signature) {
+		t.Errorf("parsed signature does not verify against the checkpoint body")
+	}
+
+	t.Run("empty checkpoint", func(t *testing.T) {
+		if _, err := parseCheckpoint(""); err == nil {
+			t.Fatal("expected error for empty checkpoint")
+		}
+	})
+
+	t.Run("missing signature line", func(t *testing.T) {
+		if _, err := parseCheckpoint(body); err == nil {
+			t.Fatal("expected error for checkpoint with no signature line")
+		}
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		bad := signCheckpoint(t, priv, "example.com/log\nnot-a-number\n"+base64.StdEncoding.EncodeToString(root), "example.com/log")
+		if _, err := parseCheckpoint(bad); err == nil {
+			t.Fatal("expected error for non-numeric tree size")
+		}
+	})
+}
+
+func TestLeafHashFromEntry(t *testing.T) {
+	entryBytes := []byte(`{"kind":"hashedrekord","apiVersion":"0.0.1"}`)
+	encoded := base64.StdEncoding.EncodeToString(entryBytes)
+
+	got, err := leafHashFromEntry(encoded)
+	if err != nil {
+		t.Fatalf("leafHashFromEntry: %v", err)
+	}
+	want := sha256.Sum256(append([]byte{0x00}, entryBytes...))
+	if !bytes.Equal(got, want[:]) {
+		t.Errorf("leaf hash = %x, want %x", got, want)
+	}
+
+	t.Run("non-string body", func(t *testing.T) {
+		if _, err := leafHashFromEntry(42); err == nil {
+			t.Fatal("expected error for a non-string entry body")
+		}
+	})
+
+	t.Run("invalid base64", func(t *testing.T) {
+		if _, err := leafHashFromEntry("not valid base64!!"); err == nil {
+			t.Fatal("expected error for a non-base64 entry body")
+		}
+	})
+}
+
+func TestVerifySTHSignatureRoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+	root := leaf('r')
+	body := checkpointBody("example.com/log", 7, root)
+	checkpoint := signCheckpoint(t, priv, body, "example.com/log")
+
+	sth, err := parseCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatalf("parseCheckpoint: %v", err)
+	}
+
+	// This is the regression this test guards: sthSignedMessage must
+	// reconstruct the full signed body, including the origin line, or a
+	// genuinely valid checkpoint signature will never verify.
+	if err := verifySTHSignature(sth, pub); err != nil {
+		t.Fatalf("verifySTHSignature rejected a genuinely signed checkpoint: %v", err)
+	}
+
+	tampered := sth
+	tampered.Origin = "attacker.example/log"
+	if err := verifySTHSignature(tampered, pub); err == nil {
+		t.Fatal("expected verifySTHSignature to reject a checkpoint with a tampered origin")
+	}
+}
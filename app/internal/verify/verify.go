@@ -0,0 +1,354 @@
+// Package verify holds the trust-decision logic for this binary's own
+// image: verifying its Cosign signature and in-toto/SLSA attestations,
+// deriving a SLSA build level from them, and evaluating the admission
+// policy gate against the result. main wires this into the HTTP server and
+// the process exit path; this package only decides, it never serves or
+// exits.
+package verify
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	slsav1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/cosign/v2/pkg/oci"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+
+	"container-sbom-signing-attestation/internal/policy"
+	"container-sbom-signing-attestation/internal/sbom"
+)
+
+// Config holds the env-supplied coordinates needed to verify the signature
+// and attestation bundle for the image this binary is running as.
+type Config struct {
+	ImageRef        string
+	CosignPublicKey string
+	FulcioRoot      string
+	RekorURL        string
+	Strict          bool
+}
+
+// LoadConfig reads Config from the env vars the deployment sets.
+func LoadConfig() Config {
+	return Config{
+		ImageRef:        os.Getenv("IMAGE_REF"),
+		CosignPublicKey: os.Getenv("COSIGN_PUBLIC_KEY"),
+		FulcioRoot:      os.Getenv("FULCIO_ROOT"),
+		RekorURL:        os.Getenv("REKOR_URL"),
+		Strict:          os.Getenv("VERIFY_STRICT") == "true",
+	}
+}
+
+// Attestation is one verified in-toto attestation found for the image. An
+// image commonly carries more than one (e.g. SLSA provenance alongside an
+// SPDX/CycloneDX SBOM attestation), so Image collects every one it verifies
+// rather than keeping only the first.
+type Attestation struct {
+	PredicateType string
+	// SBOMDigest is the subject digest this attestation asserts, populated
+	// only when PredicateType identifies it as an SBOM attestation (it
+	// identifies the attested SBOM artifact, not necessarily the image).
+	SBOMDigest string
+	// BuilderID is the SLSA provenance builder.id, populated only for SLSA
+	// provenance attestations; used by SLSABuildLevel to tell a recognized
+	// trusted builder from a self-attested one.
+	BuilderID string
+	SignedAt  time.Time
+}
+
+// Result is the outcome of verifying this image's Cosign signature and
+// SLSA/in-toto attestations. It is computed once at startup and cached so
+// the server package can serve it cheaply on every request.
+type Result struct {
+	ImageDigest       string
+	SignatureVerified bool
+	Attestations      []Attestation
+	RekorLogIndex     int64
+	SignerIdentity    string
+	SignerIssuer      string
+	Errors            []string
+}
+
+// Image fetches and verifies the Cosign signature and in-toto/SLSA
+// attestation bundle for cfg.ImageRef. Failures are recorded in
+// result.Errors rather than returned so a degraded verification never
+// prevents the process from starting when Strict is false.
+//
+// Verification requires either a cosign public key or a Fulcio root to
+// trust; with neither configured there is nothing to check against, so
+// verification is skipped rather than treated as a pass. Rekor inclusion
+// itself is verified separately and continuously by internal/transparency,
+// so the transparency log lookup here is disabled (IgnoreTlog) to avoid
+// doing it twice with two different trust paths.
+func Image(ctx context.Context, cfg Config) Result {
+	result := Result{}
+
+	if cfg.ImageRef == "" {
+		result.Errors = append(result.Errors, "IMAGE_REF not set, skipping signature/attestation verification")
+		return result
+	}
+
+	ref, err := name.ParseReference(cfg.ImageRef)
+	if err != nil {
+		result.Errors = append(result.Errors, "parsing IMAGE_REF: "+err.Error())
+		return result
+	}
+
+	if desc, err := ggcrremote.Head(ref); err != nil {
+		result.Errors = append(result.Errors, "resolving image digest: "+err.Error())
+	} else {
+		result.ImageDigest = desc.Digest.String()
+	}
+
+	co := &cosign.CheckOpts{IgnoreTlog: true}
+	switch {
+	case cfg.CosignPublicKey != "":
+		pubKey, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(cfg.CosignPublicKey))
+		if err != nil {
+			result.Errors = append(result.Errors, "parsing COSIGN_PUBLIC_KEY: "+err.Error())
+			return result
+		}
+		verifier, err := signature.LoadVerifier(pubKey, crypto.SHA256)
+		if err != nil {
+			result.Errors = append(result.Errors, "loading COSIGN_PUBLIC_KEY: "+err.Error())
+			return result
+		}
+		co.SigVerifier = verifier
+	case cfg.FulcioRoot != "":
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(cfg.FulcioRoot)) {
+			result.Errors = append(result.Errors, "parsing FULCIO_ROOT: no certificates found")
+			return result
+		}
+		co.RootCerts = pool
+	default:
+		result.Errors = append(result.Errors, "neither COSIGN_PUBLIC_KEY nor FULCIO_ROOT set, skipping signature/attestation verification")
+		return result
+	}
+
+	sigs, bundleVerified, err := cosign.VerifyImageSignatures(ctx, ref, co)
+	if err != nil {
+		result.Errors = append(result.Errors, "signature verification failed: "+err.Error())
+	} else {
+		result.SignatureVerified = bundleVerified
+		if len(sigs) > 0 {
+			populateFromSignature(&result, sigs[0])
+		}
+	}
+
+	atts, _, err := cosign.VerifyImageAttestations(ctx, ref, co)
+	if err != nil {
+		result.Errors = append(result.Errors, "attestation verification failed: "+err.Error())
+	} else {
+		for _, att := range atts {
+			if a, ok := populateFromAttestation(&result, att); ok {
+				result.Attestations = append(result.Attestations, a)
+			}
+		}
+	}
+
+	return result
+}
+
+// populateFromSignature fills the signer and Rekor fields of result from a
+// verified image signature's Fulcio certificate and Rekor bundle.
+func populateFromSignature(result *Result, sig oci.Signature) {
+	if cert, err := sig.Cert(); err == nil && cert != nil {
+		if issuer := (&cosign.CertExtensions{Cert: cert}).GetIssuer(); issuer != "" {
+			result.SignerIssuer = issuer
+		}
+		if sans := cryptoutils.GetSubjectAlternateNames(cert); len(sans) > 0 {
+			result.SignerIdentity = sans[0]
+		}
+	}
+	if b, err := sig.Bundle(); err == nil && b != nil {
+		result.RekorLogIndex = b.Payload.LogIndex
+	}
+}
+
+// populateFromAttestation decodes one verified attestation's DSSE envelope
+// into an Attestation, using decodeAttestationPayload for the part that
+// doesn't need the oci.Signature itself. It returns ok=false if the
+// envelope or statement can't be decoded, after recording the failure in
+// result.Errors; a decode failure for one attestation should not prevent
+// the others from being recorded.
+func populateFromAttestation(result *Result, att oci.Signature) (a Attestation, ok bool) {
+	payload, err := att.Payload()
+	if err != nil {
+		result.Errors = append(result.Errors, "reading attestation payload: "+err.Error())
+		return a, false
+	}
+
+	a, err = decodeAttestationPayload(payload)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return a, false
+	}
+
+	if b, err := att.Bundle(); err == nil && b != nil && b.Payload.IntegratedTime != 0 {
+		a.SignedAt = time.Unix(b.Payload.IntegratedTime, 0).UTC()
+	}
+
+	return a, true
+}
+
+// decodeAttestationPayload decodes a DSSE-enveloped in-toto statement
+// (following the same envelope/in-toto decoding cosign's own
+// IntotoSubjectClaimVerifier uses) into an Attestation, leaving SignedAt
+// zero since that comes from the Rekor bundle rather than the payload.
+// Split out from populateFromAttestation so the decoding logic can be
+// exercised directly with literal DSSE payloads, without faking the
+// oci.Signature interface.
+func decodeAttestationPayload(payload []byte) (Attestation, error) {
+	var a Attestation
+
+	var envelope dsse.Envelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return a, fmt.Errorf("parsing attestation envelope: %w", err)
+	}
+	stBytes, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return a, fmt.Errorf("decoding attestation statement: %w", err)
+	}
+	var statement in_toto.Statement
+	if err := json.Unmarshal(stBytes, &statement); err != nil {
+		return a, fmt.Errorf("parsing attestation statement: %w", err)
+	}
+
+	a.PredicateType = statement.PredicateType
+	for _, subj := range statement.Subject {
+		if dgst, ok := subj.Digest["sha256"]; ok {
+			if strings.Contains(statement.PredicateType, "spdx") || strings.Contains(statement.PredicateType, "cyclonedx") {
+				a.SBOMDigest = "sha256:" + dgst
+			}
+			break
+		}
+	}
+
+	if statement.PredicateType == slsav1.PredicateSLSAProvenance {
+		if predBytes, err := json.Marshal(statement.Predicate); err == nil {
+			var provenance slsav1.ProvenancePredicate
+			if err := json.Unmarshal(predBytes, &provenance); err == nil {
+				a.BuilderID = provenance.RunDetails.Builder.ID
+			}
+		}
+	}
+
+	return a, nil
+}
+
+// trustedSLSABuilders maps SLSA provenance builder.id values this
+// deployment recognizes as hermetic, isolated build platforms to the SLSA
+// Build level they're trusted to attain. A builder ID present in a
+// verified attestation but absent from this table is treated as
+// self-attested (level 1): the provenance format asserts *something* built
+// the image, but nothing here independently verifies that builder actually
+// provides the isolation SLSA Build L3 requires.
+var trustedSLSABuilders = map[string]int{
+	"https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml": 3,
+}
+
+// SLSABuildLevel derives the highest SLSA build level implied by atts: a
+// SLSA v1 provenance attestation from a recognized trustedSLSABuilders
+// entry claims that builder's level; one from an unrecognized builder only
+// claims level 1; no SLSA provenance attestation at all is level 0. Unlike
+// trusting any SLSA-provenance-shaped predicate type regardless of
+// content, this only credits a level the attesting builder is actually
+// known to be capable of.
+func SLSABuildLevel(atts []Attestation) int {
+	level := 0
+	for _, a := range atts {
+		if a.PredicateType != slsav1.PredicateSLSAProvenance {
+			continue
+		}
+		builderLevel := 1
+		if trusted, ok := trustedSLSABuilders[a.BuilderID]; ok {
+			builderLevel = trusted
+		}
+		if builderLevel > level {
+			level = builderLevel
+		}
+	}
+	return level
+}
+
+// PolicyOutcome is the result of EvaluatePolicy: the evaluated policy
+// result (nil if no policy is configured) and whether the caller must exit
+// before serving.
+type PolicyOutcome struct {
+	Result     *policy.Result
+	ExitNeeded bool
+	// VulnerabilitySummaryError is set when the SBOM provider's
+	// vulnerability summary couldn't be loaded; maxVulnerabilities rules
+	// still evaluated (and failed closed, see policy.Input.VulnerabilitiesUnavailable),
+	// but main logs this separately so the operator knows why.
+	VulnerabilitySummaryError error
+}
+
+// EvaluatePolicy loads and evaluates the policy configured via POLICY_FILE,
+// if any, against verifyResult and the SBOM provider's vulnerability
+// summary. It returns a zero PolicyOutcome (Result == nil) when no policy
+// is configured. ExitNeeded is true only when the policy fails and the
+// configured mode is enforce; the caller (main) is responsible for acting
+// on it, so this function never calls os.Exit itself.
+func EvaluatePolicy(verifyResult Result, sbomProvider *sbom.Provider) (PolicyOutcome, error) {
+	policyFile := os.Getenv("POLICY_FILE")
+	if policyFile == "" {
+		return PolicyOutcome{}, nil
+	}
+
+	mode := policy.ModeWarn
+	if os.Getenv("POLICY_MODE") == "enforce" {
+		mode = policy.ModeEnforce
+	}
+
+	engine, err := policy.Load(policyFile, mode)
+	if err != nil {
+		return PolicyOutcome{}, fmt.Errorf("loading policy: %w", err)
+	}
+
+	summary, _, vulnErr := sbomProvider.Vulnerabilities()
+
+	input := policy.Input{
+		ImageDigest:                verifyResult.ImageDigest,
+		SignatureVerified:          verifyResult.SignatureVerified,
+		SignerIssuer:               verifyResult.SignerIssuer,
+		SignerIdentity:             verifyResult.SignerIdentity,
+		SLSABuildLevel:             SLSABuildLevel(verifyResult.Attestations),
+		VulnerabilitiesUnavailable: vulnErr != nil,
+		Vulnerabilities: map[string]int{
+			"critical": summary.Critical,
+			"high":     summary.High,
+			"medium":   summary.Medium,
+			"low":      summary.Low,
+			"unknown":  summary.Unknown,
+		},
+	}
+	for _, a := range verifyResult.Attestations {
+		input.AttestationPredicateTypes = append(input.AttestationPredicateTypes, a.PredicateType)
+	}
+
+	result, err := engine.Evaluate(context.Background(), input)
+	if err != nil {
+		return PolicyOutcome{}, fmt.Errorf("evaluating policy: %w", err)
+	}
+
+	return PolicyOutcome{
+		Result:                    &result,
+		ExitNeeded:                !result.Passed && mode == policy.ModeEnforce,
+		VulnerabilitySummaryError: vulnErr,
+	}, nil
+}
@@ -0,0 +1,204 @@
+package verify
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"container-sbom-signing-attestation/internal/sbom"
+)
+
+func TestSLSABuildLevel(t *testing.T) {
+	t.Run("no SLSA provenance attestation is level 0", func(t *testing.T) {
+		atts := []Attestation{{PredicateType: "https://spdx.dev/Document"}}
+		if got := SLSABuildLevel(atts); got != 0 {
+			t.Errorf("SLSABuildLevel = %d, want 0", got)
+		}
+	})
+
+	t.Run("unrecognized builder is level 1", func(t *testing.T) {
+		atts := []Attestation{{
+			PredicateType: "https://slsa.dev/provenance/v1",
+			BuilderID:     "https://example.com/unknown-builder",
+		}}
+		if got := SLSABuildLevel(atts); got != 1 {
+			t.Errorf("SLSABuildLevel = %d, want 1", got)
+		}
+	})
+
+	t.Run("trusted builder claims its trusted level", func(t *testing.T) {
+		atts := []Attestation{{
+			PredicateType: "https://slsa.dev/provenance/v1",
+			BuilderID:     "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml",
+		}}
+		if got := SLSABuildLevel(atts); got != 3 {
+			t.Errorf("SLSABuildLevel = %d, want 3", got)
+		}
+	})
+
+	t.Run("highest level wins across multiple attestations", func(t *testing.T) {
+		atts := []Attestation{
+			{PredicateType: "https://slsa.dev/provenance/v1", BuilderID: "https://example.com/unknown-builder"},
+			{PredicateType: "https://slsa.dev/provenance/v1", BuilderID: "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml"},
+		}
+		if got := SLSABuildLevel(atts); got != 3 {
+			t.Errorf("SLSABuildLevel = %d, want 3", got)
+		}
+	})
+}
+
+func TestDecodeAttestationPayload(t *testing.T) {
+	statement := func(predicateType string, subjectDigest string, builderID string) []byte {
+		st := map[string]any{
+			"_type":         "https://in-toto.io/Statement/v0.1",
+			"predicateType": predicateType,
+			"subject": []map[string]any{
+				{"name": "image", "digest": map[string]string{"sha256": subjectDigest}},
+			},
+		}
+		if builderID != "" {
+			st["predicate"] = map[string]any{
+				"buildDefinition": map[string]any{},
+				"runDetails": map[string]any{
+					"builder": map[string]any{"id": builderID},
+				},
+			}
+		}
+		raw, _ := json.Marshal(st)
+		return raw
+	}
+	envelope := func(payload []byte) []byte {
+		raw, _ := json.Marshal(map[string]any{
+			"payloadType": "application/vnd.in-toto+json",
+			"payload":     base64.StdEncoding.EncodeToString(payload),
+			"signatures":  []any{},
+		})
+		return raw
+	}
+
+	t.Run("SPDX SBOM attestation carries its subject digest", func(t *testing.T) {
+		payload := envelope(statement("https://spdx.dev/Document", "abc123", ""))
+		a, err := decodeAttestationPayload(payload)
+		if err != nil {
+			t.Fatalf("decodeAttestationPayload: %v", err)
+		}
+		if a.PredicateType != "https://spdx.dev/Document" {
+			t.Errorf("PredicateType = %q, want %q", a.PredicateType, "https://spdx.dev/Document")
+		}
+		if a.SBOMDigest != "sha256:abc123" {
+			t.Errorf("SBOMDigest = %q, want %q", a.SBOMDigest, "sha256:abc123")
+		}
+	})
+
+	t.Run("SLSA provenance attestation carries its builder ID, not an SBOM digest", func(t *testing.T) {
+		payload := envelope(statement("https://slsa.dev/provenance/v1", "def456", "https://example.com/builder"))
+		a, err := decodeAttestationPayload(payload)
+		if err != nil {
+			t.Fatalf("decodeAttestationPayload: %v", err)
+		}
+		if a.BuilderID != "https://example.com/builder" {
+			t.Errorf("BuilderID = %q, want %q", a.BuilderID, "https://example.com/builder")
+		}
+		if a.SBOMDigest != "" {
+			t.Errorf("SBOMDigest = %q, want empty for a non-SBOM predicate type", a.SBOMDigest)
+		}
+	})
+
+	t.Run("malformed envelope", func(t *testing.T) {
+		if _, err := decodeAttestationPayload([]byte("not json")); err == nil {
+			t.Fatal("expected error for malformed envelope")
+		}
+	})
+
+	t.Run("envelope payload is not valid base64", func(t *testing.T) {
+		raw, _ := json.Marshal(map[string]any{"payloadType": "x", "payload": "not-base64!!", "signatures": []any{}})
+		if _, err := decodeAttestationPayload(raw); err == nil {
+			t.Fatal("expected error for non-base64 envelope payload")
+		}
+	})
+}
+
+func writePolicyFile(t *testing.T, contents string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing policy file: %v", err)
+	}
+	return path
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	sbomProvider := sbom.NewProvider("", nil)
+
+	t.Run("no POLICY_FILE configured returns a zero outcome", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", "")
+		outcome, err := EvaluatePolicy(Result{SignatureVerified: true}, sbomProvider)
+		if err != nil {
+			t.Fatalf("EvaluatePolicy: %v", err)
+		}
+		if outcome.Result != nil || outcome.ExitNeeded {
+			t.Errorf("outcome = %+v, want zero value", outcome)
+		}
+	})
+
+	t.Run("enforce mode requires exit on failure", func(t *testing.T) {
+		policyFile := writePolicyFile(t, "requiredIssuer: https://accounts.example.com\n")
+		t.Setenv("POLICY_FILE", policyFile)
+		t.Setenv("POLICY_MODE", "enforce")
+
+		outcome, err := EvaluatePolicy(Result{SignatureVerified: true, SignerIssuer: "https://evil.example.com"}, sbomProvider)
+		if err != nil {
+			t.Fatalf("EvaluatePolicy: %v", err)
+		}
+		if outcome.Result == nil || outcome.Result.Passed {
+			t.Fatal("expected the policy to fail")
+		}
+		if !outcome.ExitNeeded {
+			t.Error("expected ExitNeeded in enforce mode on failure")
+		}
+	})
+
+	t.Run("warn mode never requires exit", func(t *testing.T) {
+		policyFile := writePolicyFile(t, "requiredIssuer: https://accounts.example.com\n")
+		t.Setenv("POLICY_FILE", policyFile)
+		t.Setenv("POLICY_MODE", "warn")
+
+		outcome, err := EvaluatePolicy(Result{SignatureVerified: true, SignerIssuer: "https://evil.example.com"}, sbomProvider)
+		if err != nil {
+			t.Fatalf("EvaluatePolicy: %v", err)
+		}
+		if outcome.Result == nil || outcome.Result.Passed {
+			t.Fatal("expected the policy to fail")
+		}
+		if outcome.ExitNeeded {
+			t.Error("did not expect ExitNeeded in warn mode")
+		}
+	})
+
+	t.Run("passing policy never requires exit, even in enforce mode", func(t *testing.T) {
+		policyFile := writePolicyFile(t, "requiredIssuer: https://accounts.example.com\n")
+		t.Setenv("POLICY_FILE", policyFile)
+		t.Setenv("POLICY_MODE", "enforce")
+
+		outcome, err := EvaluatePolicy(Result{SignatureVerified: true, SignerIssuer: "https://accounts.example.com"}, sbomProvider)
+		if err != nil {
+			t.Fatalf("EvaluatePolicy: %v", err)
+		}
+		if outcome.Result == nil || !outcome.Result.Passed {
+			t.Fatal("expected the policy to pass")
+		}
+		if outcome.ExitNeeded {
+			t.Error("did not expect ExitNeeded for a passing policy")
+		}
+	})
+
+	t.Run("missing policy file surfaces an error instead of fataling", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", filepath.Join(t.TempDir(), "does-not-exist.yaml"))
+		if _, err := EvaluatePolicy(Result{}, sbomProvider); err == nil {
+			t.Fatal("expected an error for a missing policy file")
+		}
+	})
+}
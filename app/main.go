@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"runtime"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"container-sbom-signing-attestation/internal/metrics"
+	"container-sbom-signing-attestation/internal/policy"
+	"container-sbom-signing-attestation/internal/registry"
+	"container-sbom-signing-attestation/internal/sbom"
+	"container-sbom-signing-attestation/internal/server"
+	"container-sbom-signing-attestation/internal/transparency"
+	"container-sbom-signing-attestation/internal/verify"
 )
 
 // Version info - populated at build time
@@ -17,27 +26,40 @@ var (
 	GitCommit = "unknown"
 )
 
-type HealthResponse struct {
-	Status    string `json:"status"`
-	Version   string `json:"version"`
-	BuildTime string `json:"build_time"`
-	GitCommit string `json:"git_commit"`
-	GoVersion string `json:"go_version"`
-	Timestamp string `json:"timestamp"`
-}
-
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	response := HealthResponse{
-		Status:    "healthy",
-		Version:   Version,
-		BuildTime: BuildTime,
-		GitCommit: GitCommit,
-		GoVersion: runtime.Version(),
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
+// loadTransparencyConfig reads the env vars used to track the transparency
+// log entry for this image's signature. An empty EntryUUID or LogPublicKey
+// means transparency tracking is disabled.
+func loadTransparencyConfig(rekorURL string) transparency.Config {
+	pollInterval := 10 * time.Minute
+	if v := os.Getenv("TRANSPARENCY_POLL_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			pollInterval = d
+		}
+	}
+	maxStaleness := 30 * time.Minute
+	if v := os.Getenv("TRANSPARENCY_MAX_STALENESS"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			maxStaleness = d
+		}
 	}
+	return transparency.Config{
+		RekorURL:     rekorURL,
+		LogPublicKey: os.Getenv("TRANSPARENCY_LOG_PUBLIC_KEY"),
+		EntryUUID:    os.Getenv("TRANSPARENCY_ENTRY_UUID"),
+		StateFile:    os.Getenv("TRANSPARENCY_STATE_FILE"),
+		PollInterval: pollInterval,
+		MaxStaleness: maxStaleness,
+	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// policyFailureRecord is logged as a single JSON line when the policy gate
+// fails in enforce mode, so it can be picked up by log-based alerting
+// without parsing free-form text.
+type policyFailureRecord struct {
+	Level   string              `json:"level"`
+	Message string              `json:"msg"`
+	Mode    policy.Mode         `json:"mode"`
+	Rules   []policy.RuleResult `json:"failed_rules"`
 }
 
 func main() {
@@ -46,13 +68,118 @@ func main() {
 		port = "8080"
 	}
 
-	http.HandleFunc("/health", healthHandler)
-	http.HandleFunc("/", healthHandler)
+	verifyCfg := verify.LoadConfig()
+	verifyResult := verify.Image(context.Background(), verifyCfg)
+	for _, e := range verifyResult.Errors {
+		log.Printf("image verification: %s", e)
+	}
+
+	var transparencyClient *transparency.Client
+	transparencyCfg := loadTransparencyConfig(verifyCfg.RekorURL)
+	if transparencyCfg.EntryUUID != "" && transparencyCfg.LogPublicKey != "" {
+		client, err := transparency.NewClient(transparencyCfg)
+		if err != nil {
+			log.Printf("transparency: disabled, %v", err)
+		} else {
+			transparencyClient = client
+		}
+	}
+
+	var registryClient sbom.Registry
+	if verifyCfg.ImageRef != "" {
+		registryClient = registry.NewClient()
+	}
+	sbomProvider := sbom.NewProvider(verifyCfg.ImageRef, registryClient)
+
+	appMetrics := metrics.New()
+
+	policyOutcome, err := verify.EvaluatePolicy(verifyResult, sbomProvider)
+	if err != nil {
+		log.Fatalf("policy: %v", err)
+	}
+	if policyOutcome.VulnerabilitySummaryError != nil {
+		log.Printf("policy: could not load vulnerability summary: %v", policyOutcome.VulnerabilitySummaryError)
+	}
+	if policyOutcome.Result != nil && !policyOutcome.Result.Passed {
+		if policyOutcome.ExitNeeded {
+			var failed []policy.RuleResult
+			for _, rule := range policyOutcome.Result.Rules {
+				if !rule.Passed {
+					failed = append(failed, rule)
+				}
+			}
+			record, _ := json.Marshal(policyFailureRecord{
+				Level:   "error",
+				Message: "policy gate failed in enforce mode",
+				Mode:    policyOutcome.Result.Mode,
+				Rules:   failed,
+			})
+			log.Println(string(record))
+			os.Exit(1)
+		}
+		log.Printf("policy: failed in warn mode, serving degraded")
+	}
+	policyResult := policyOutcome.Result
+
+	verificationStatus := server.VerificationStatus{
+		ImageDigest:       verifyResult.ImageDigest,
+		SignatureVerified: verifyResult.SignatureVerified,
+		RekorLogIndex:     verifyResult.RekorLogIndex,
+		SignerIssuer:      verifyResult.SignerIssuer,
+		SignerIdentity:    verifyResult.SignerIdentity,
+		Errors:            verifyResult.Errors,
+		Strict:            verifyCfg.Strict,
+	}
+	for _, a := range verifyResult.Attestations {
+		verificationStatus.Attestations = append(verificationStatus.Attestations, server.Attestation{
+			PredicateType: a.PredicateType,
+			SBOMDigest:    a.SBOMDigest,
+			BuilderID:     a.BuilderID,
+			SignedAt:      a.SignedAt,
+		})
+	}
+
+	srv := server.New(
+		server.BuildInfo{Version: Version, BuildTime: BuildTime, GitCommit: GitCommit},
+		verificationStatus,
+		transparencyClient,
+		sbomProvider,
+		appMetrics,
+		policyResult,
+	)
+	srv.RefreshMetrics()
+	if transparencyClient != nil {
+		transparencyClient.OnUpdate(func(transparency.Status) { srv.RefreshMetrics() })
+		transparencyClient.Start(nil)
+	}
+
+	httpServer := &http.Server{
+		Addr:    ":" + port,
+		Handler: srv.Routes(),
+	}
 
 	log.Printf("Supply Chain Demo App v%s starting on port %s", Version, port)
 	log.Printf("Build: %s | Commit: %s", BuildTime, GitCommit)
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatal(err)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	case sig := <-stop:
+		log.Printf("received %s, shutting down", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			log.Printf("graceful shutdown failed: %v", err)
+		}
 	}
 }